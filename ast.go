@@ -6,9 +6,15 @@ package sh
 import (
 	"bytes"
 	"fmt"
+
+	"github.com/dominikh/sh/token"
 )
 
-var defaultPos = Pos{}
+// Pos is a compact source position. See the token package for how to
+// turn it back into a filename, line, and column.
+type Pos = token.Pos
+
+var defaultPos = token.NoPos
 
 func nodeFirstPos(ns []Node) Pos {
 	if len(ns) == 0 {
@@ -24,14 +30,48 @@ func wordFirstPos(ws []Word) Pos {
 	return ws[0].Pos()
 }
 
-// File is a shell program.
+func stmtFirstPos(stmts []Stmt) Pos {
+	if len(stmts) == 0 {
+		return defaultPos
+	}
+	return stmts[0].Pos()
+}
+
+// File is a shell program. Its name lives in its FileSet, the single
+// owner of filenames since Pos values are only meaningful relative to
+// one; Name looks it back up rather than duplicating it here.
 type File struct {
-	Name string
+	Fset *token.FileSet
 
-	Stmts []Stmt
+	Stmts    []Stmt
+	Comments []Comment
 }
 
 func (f File) String() string { return stmtJoinWithEnd(f.Stmts, false) }
+func (f File) Pos() Pos       { return stmtFirstPos(f.Stmts) }
+
+// Name returns the file's name, as recorded in its FileSet. It
+// returns the empty string if f has no FileSet, or if f's position
+// is unknown to it.
+func (f File) Name() string {
+	if f.Fset == nil {
+		return ""
+	}
+	if tf := f.Fset.File(f.Pos()); tf != nil {
+		return tf.Name()
+	}
+	return ""
+}
+
+// Position returns the filename, line, and column that pos refers to
+// within f, using f's FileSet. It returns the zero Position if f has
+// no FileSet, such as for a File built by hand in a test.
+func (f File) Position(pos Pos) token.Position {
+	if f.Fset == nil {
+		return token.Position{}
+	}
+	return f.Fset.Position(pos)
+}
 
 // Node represents an AST node.
 type Node interface {
@@ -162,6 +202,7 @@ type Redirect struct {
 func (r Redirect) String() string {
 	return fmt.Sprintf("%s%s%s", r.N, r.Op, r.Word)
 }
+func (r Redirect) Pos() Pos { return r.OpPos }
 
 type Command struct {
 	Args []Word
@@ -227,6 +268,7 @@ type Elif struct {
 func (e Elif) String() string {
 	return fmt.Sprint(ELIF, stmtList(e.Conds), THEN, stmtList(e.ThenStmts))
 }
+func (e Elif) Pos() Pos { return e.Elif }
 
 type WhileStmt struct {
 	While, Done Pos
@@ -400,3 +442,4 @@ type PatternList struct {
 func (p PatternList) String() string {
 	return fmt.Sprintf("%s) %s", wordJoin(p.Patterns, " | "), stmtJoin(p.Stmts))
 }
+func (p PatternList) Pos() Pos { return wordFirstPos(p.Patterns) }
@@ -0,0 +1,31 @@
+// Copyright (c) 2016, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package sh
+
+// A Comment represents a single # comment.
+type Comment struct {
+	Hash Pos
+	Text string
+}
+
+func (c Comment) String() string { return "#" + c.Text }
+func (c Comment) Pos() Pos       { return c.Hash }
+
+// A CommentGroup represents a sequence of comments on consecutive
+// lines, with no empty lines between them. Grouping goes by line
+// numbers only, so a statement that shares a line with one of the
+// comments does not split the group.
+type CommentGroup struct {
+	Comments []Comment
+}
+
+func (g CommentGroup) Pos() Pos { return nodeFirstPos(commentNodes(g.Comments)) }
+
+func commentNodes(cs []Comment) []Node {
+	ns := make([]Node, len(cs))
+	for i, c := range cs {
+		ns[i] = c
+	}
+	return ns
+}
@@ -0,0 +1,278 @@
+// Copyright (c) 2016, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package sh
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/dominikh/sh/token"
+)
+
+// A CommentMap associates comment groups with the nodes they most
+// likely document, following the same line-adjacency rules as
+// go/ast's CommentMap: a comment group on the line before a node is
+// its lead comment, a comment group on the same line as a node is its
+// line comment, and anything else floats and is attached to the
+// nearest enclosing block instead.
+//
+// Nodes are value types with slice fields, so they cannot be used as
+// map keys directly; CommentMap instead keys its entries by
+// commentKey, a node's Pos paired with its concrete type. The pair is
+// needed because two different nodes can share a Pos: a Stmt and the
+// BinaryExpr it wraps both start at the same byte, for instance. Use
+// Filter, Update, or CommentMap.Comments rather than indexing the map
+// directly, since commentKey is unexported.
+type CommentMap map[commentKey][]*CommentGroup
+
+type commentKey struct {
+	pos  Pos
+	kind reflect.Type
+}
+
+func keyFor(n Node) commentKey { return commentKey{n.Pos(), reflect.TypeOf(n)} }
+
+// Comments returns the comment groups associated with n.
+func (cm CommentMap) Comments(n Node) []*CommentGroup { return cm[keyFor(n)] }
+
+// NewCommentMap builds a CommentMap for file's comments. fset is used
+// to resolve the line each comment and each Stmt starts on.
+func NewCommentMap(fset *token.FileSet, file File, comments []Comment) CommentMap {
+	cm := make(CommentMap)
+	groups := groupComments(fset, comments)
+	if len(groups) == 0 {
+		return cm
+	}
+
+	c := blockCollector{fset: fset}
+	c.block(keyFor(file), file.Stmts)
+	sort.Slice(c.stmts, func(i, j int) bool { return c.stmts[i].line < c.stmts[j].line })
+	// Sort innermost (smallest line span) first, so the search below
+	// finds the nearest enclosing block rather than the file.
+	// The sort is stable because inner blocks are recorded before the
+	// blocks that contain them, so among equal spans the inner one
+	// still comes first.
+	sort.SliceStable(c.blocks, func(i, j int) bool {
+		return c.blocks[i].max-c.blocks[i].min < c.blocks[j].max-c.blocks[j].min
+	})
+
+	for _, g := range groups {
+		first := fset.Position(g.Comments[0].Pos()).Line
+		last := fset.Position(g.Comments[len(g.Comments)-1].Pos()).Line
+
+		dst := keyFor(file)
+		found := false
+		for _, s := range c.stmts {
+			switch s.line {
+			case last + 1:
+				// A comment group directly above a
+				// statement is its lead comment.
+				dst, found = s.key, true
+			case first:
+				// A comment group starting on the same
+				// line as a statement is its line comment.
+				if !found {
+					dst, found = s.key, true
+				}
+			}
+		}
+		if !found {
+			// Nothing claimed it; it floats, so attach it to
+			// the nearest enclosing block that spans it.
+			for _, blk := range c.blocks {
+				if first >= blk.min && last <= blk.max {
+					dst = blk.key
+					break
+				}
+			}
+		}
+		cm[dst] = append(cm[dst], g)
+	}
+	return cm
+}
+
+type stmtLine struct {
+	key  commentKey
+	line int
+}
+
+type blockSpan struct {
+	key      commentKey
+	min, max int
+}
+
+// blockCollector walks the statement lists that make up a File,
+// recording the line each statement starts on plus the line span of
+// every block-like container, so that floating comments can be
+// matched to the nearest one that contains them.
+type blockCollector struct {
+	fset   *token.FileSet
+	stmts  []stmtLine
+	blocks []blockSpan
+}
+
+// block records stmts as the direct children of the container
+// identified by key, and returns the line span covering all of them
+// (and anything they nest).
+func (c *blockCollector) block(key commentKey, stmts []Stmt) (min, max int) {
+	min, max = -1, -1
+	for _, s := range stmts {
+		line := c.fset.Position(s.Pos()).Line
+		c.stmts = append(c.stmts, stmtLine{keyFor(s), line})
+		lo, hi := line, line
+		if s.Node != nil {
+			if l, h, ok := c.nested(s.Node); ok {
+				lo, hi = minInt(lo, l), maxInt(hi, h)
+			}
+		}
+		if min == -1 || lo < min {
+			min = lo
+		}
+		if max == -1 || hi > max {
+			max = hi
+		}
+	}
+	if min == -1 {
+		line := c.fset.Position(key.pos).Line
+		min, max = line, line
+	}
+	c.blocks = append(c.blocks, blockSpan{key, min, max})
+	return min, max
+}
+
+// nested recurses into n's own statement lists, if it has any, and
+// reports the combined line span.
+func (c *blockCollector) nested(n Node) (min, max int, ok bool) {
+	switch x := n.(type) {
+	case Block:
+		min, max = c.block(keyFor(x), x.Stmts)
+		max = maxInt(max, c.line(x.Rbrace))
+	case Subshell:
+		min, max = c.block(keyFor(x), x.Stmts)
+		max = maxInt(max, c.line(x.Rparen))
+	case CmdSubst:
+		min, max = c.block(keyFor(x), x.Stmts)
+		max = maxInt(max, c.line(x.Right))
+	case IfStmt:
+		min, max = c.combine(keyFor(x), x.Conds, x.ThenStmts)
+		for _, e := range x.Elifs {
+			l, h := c.combine(keyFor(e), e.Conds, e.ThenStmts)
+			min, max = minInt(min, l), maxInt(max, h)
+		}
+		if len(x.ElseStmts) > 0 {
+			l, h := c.block(keyFor(x), x.ElseStmts)
+			min, max = minInt(min, l), maxInt(max, h)
+		}
+		max = maxInt(max, c.line(x.Fi))
+	case WhileStmt:
+		min, max = c.combine(keyFor(x), x.Conds, x.DoStmts)
+		max = maxInt(max, c.line(x.Done))
+	case UntilStmt:
+		min, max = c.combine(keyFor(x), x.Conds, x.DoStmts)
+		max = maxInt(max, c.line(x.Done))
+	case ForStmt:
+		min, max = c.block(keyFor(x), x.DoStmts)
+		max = maxInt(max, c.line(x.Done))
+	case CaseStmt:
+		min, max = -1, -1
+		for _, pl := range x.List {
+			l, h := c.block(keyFor(pl), pl.Stmts)
+			min, max = minInt(min, l), maxInt(max, h)
+		}
+		max = maxInt(max, c.line(x.Esac))
+	case FuncDecl:
+		min, max = c.block(keyFor(x), []Stmt{x.Body})
+	default:
+		return 0, 0, false
+	}
+	// The sub-blocks above (Conds, ThenStmts, ...) were recorded
+	// under x's key with their own narrow spans; also record x's
+	// full span, extended to its closing keyword, so a comment
+	// floating between the last statement and that keyword still
+	// resolves to x rather than bubbling up further than it should.
+	c.blocks = append(c.blocks, blockSpan{keyFor(n), min, max})
+	return min, max, true
+}
+
+func (c *blockCollector) line(pos Pos) int { return c.fset.Position(pos).Line }
+
+func (c *blockCollector) combine(key commentKey, a, b []Stmt) (int, int) {
+	l1, h1 := c.block(key, a)
+	l2, h2 := c.block(key, b)
+	return minInt(l1, l2), maxInt(h1, h2)
+}
+
+// minInt and maxInt treat -1 as "no value yet", the sentinel used
+// while a block's line span is still being accumulated.
+func minInt(a, b int) int {
+	if a == -1 {
+		return b
+	}
+	if b == -1 {
+		return a
+	}
+	if b < a {
+		return b
+	}
+	return a
+}
+
+func maxInt(a, b int) int {
+	if a == -1 {
+		return b
+	}
+	if b == -1 {
+		return a
+	}
+	if b > a {
+		return b
+	}
+	return a
+}
+
+// groupComments merges consecutive comments with no blank line
+// between them into CommentGroups.
+func groupComments(fset *token.FileSet, comments []Comment) []*CommentGroup {
+	var groups []*CommentGroup
+	var cur *CommentGroup
+	prevLine := -1
+	for _, c := range comments {
+		line := fset.Position(c.Hash).Line
+		if cur != nil && line == prevLine+1 {
+			cur.Comments = append(cur.Comments, c)
+		} else {
+			cur = &CommentGroup{Comments: []Comment{c}}
+			groups = append(groups, cur)
+		}
+		prevLine = line
+	}
+	return groups
+}
+
+// Filter returns a new CommentMap containing only the entries whose
+// node appears in the tree rooted at node.
+func (cm CommentMap) Filter(node Node) CommentMap {
+	out := make(CommentMap)
+	Inspect(node, func(n Node) bool {
+		if n == nil {
+			return false
+		}
+		if groups, ok := cm[keyFor(n)]; ok {
+			out[keyFor(n)] = groups
+		}
+		return true
+	})
+	return out
+}
+
+// Update moves the comment groups associated with old over to new,
+// so that a tool rewriting old into new does not drop its comments.
+func (cm CommentMap) Update(old, new Node) {
+	groups, ok := cm[keyFor(old)]
+	if !ok {
+		return
+	}
+	delete(cm, keyFor(old))
+	cm[keyFor(new)] = append(cm[keyFor(new)], groups...)
+}
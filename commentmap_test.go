@@ -0,0 +1,80 @@
+// Copyright (c) 2016, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package sh_test
+
+import (
+	"testing"
+
+	"github.com/dominikh/sh"
+	"github.com/dominikh/sh/token"
+)
+
+func cmdStmt(pos sh.Pos, name string) sh.Stmt {
+	lit := sh.Lit{ValuePos: pos, Value: name}
+	return sh.Stmt{Position: pos, Node: sh.Command{Args: []sh.Word{{Parts: []sh.Node{lit}}}}}
+}
+
+// newLinedFile builds a FileSet with one file long enough to hold len(offsets)
+// lines starting at the given byte offsets, and returns the fset along with
+// the file's base offset.
+func newLinedFile(t *testing.T, offsets ...int) (*token.FileSet, int) {
+	t.Helper()
+	fset := token.NewFileSet()
+	tf := fset.AddFile("t.sh", offsets[len(offsets)-1]+50)
+	for _, o := range offsets {
+		tf.AddLine(o)
+	}
+	return fset, tf.Base()
+}
+
+func TestNewCommentMapLeadComment(t *testing.T) {
+	fset, base := newLinedFile(t, 0, 20)
+	// line 1: # lead
+	// line 2: echo
+	comment := sh.Comment{Hash: sh.Pos(base + 2), Text: " lead"}
+	stmt := cmdStmt(sh.Pos(base+20), "echo")
+	f := sh.File{Fset: fset, Stmts: []sh.Stmt{stmt}, Comments: []sh.Comment{comment}}
+
+	cm := sh.NewCommentMap(fset, f, f.Comments)
+	groups := cm.Comments(stmt)
+	if len(groups) != 1 || len(groups[0].Comments) != 1 || groups[0].Comments[0].Text != " lead" {
+		t.Fatalf("lead comment not attached to statement: %#v", groups)
+	}
+}
+
+func TestNewCommentMapLineComment(t *testing.T) {
+	fset, base := newLinedFile(t, 0, 20)
+	// line 1: echo # trailing
+	stmt := cmdStmt(sh.Pos(base), "echo")
+	comment := sh.Comment{Hash: sh.Pos(base + 10), Text: " trailing"}
+	f := sh.File{Fset: fset, Stmts: []sh.Stmt{stmt}, Comments: []sh.Comment{comment}}
+
+	cm := sh.NewCommentMap(fset, f, f.Comments)
+	groups := cm.Comments(stmt)
+	if len(groups) != 1 || groups[0].Comments[0].Text != " trailing" {
+		t.Fatalf("line comment not attached to statement: %#v", groups)
+	}
+}
+
+func TestNewCommentMapFloatingComment(t *testing.T) {
+	fset, base := newLinedFile(t, 0, 20, 40, 60)
+	// line 1: if true then
+	// line 2:   body
+	// line 3:   # floating
+	// line 4: fi
+	cond := cmdStmt(sh.Pos(base), "true")
+	body := cmdStmt(sh.Pos(base+20), "body")
+	ifs := sh.IfStmt{If: sh.Pos(base), Fi: sh.Pos(base + 60), Conds: []sh.Stmt{cond}, ThenStmts: []sh.Stmt{body}}
+	outer := sh.Stmt{Position: sh.Pos(base), Node: ifs}
+	comment := sh.Comment{Hash: sh.Pos(base + 42), Text: " floating"}
+	f := sh.File{Fset: fset, Stmts: []sh.Stmt{outer}, Comments: []sh.Comment{comment}}
+
+	cm := sh.NewCommentMap(fset, f, f.Comments)
+	if groups := cm.Comments(ifs); len(groups) != 1 {
+		t.Fatalf("floating comment landed on the if statement %d times, want 1", len(groups))
+	}
+	if groups := cm.Comments(f); len(groups) != 0 {
+		t.Fatalf("floating comment leaked to the file root: %#v", groups)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2016, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package sh
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/dominikh/sh/token"
+)
+
+// Fdump writes a self-describing, indented dump of n to w: one field
+// per line, with the concrete type of every node and the value of
+// every exported field. It is meant for debugging the parser and for
+// writing tests, not for production output.
+//
+// If fset is non-nil, Pos fields are decoded into file:line:column
+// form; otherwise they are printed as raw offsets.
+func Fdump(w io.Writer, fset *token.FileSet, n Node) error {
+	d := dumper{w: w, fset: fset}
+	d.dump(reflect.ValueOf(n))
+	_, err := w.Write([]byte("\n"))
+	return err
+}
+
+// Sdump is like Fdump, but it returns the result as a string.
+func Sdump(fset *token.FileSet, n Node) string {
+	var b bytes.Buffer
+	Fdump(&b, fset, n)
+	return b.String()
+}
+
+type dumper struct {
+	w     io.Writer
+	fset  *token.FileSet
+	level int
+}
+
+func (d *dumper) printf(format string, args ...interface{}) {
+	fmt.Fprintf(d.w, format, args...)
+}
+
+func (d *dumper) indent() {
+	for i := 0; i < d.level; i++ {
+		d.printf("    ")
+	}
+}
+
+// dump writes v, which must hold a Node, a Pos, a slice, or a
+// primitive value reachable from one.
+func (d *dumper) dump(v reflect.Value) {
+	if !v.IsValid() {
+		d.printf("nil")
+		return
+	}
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			d.printf("nil")
+			return
+		}
+		v = v.Elem()
+	}
+
+	if p, ok := v.Interface().(Pos); ok {
+		d.dumpPos(p)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Len() == 0 {
+			d.printf("[]")
+			return
+		}
+		d.printf("[\n")
+		d.level++
+		for i := 0; i < v.Len(); i++ {
+			d.indent()
+			d.printf("%d: ", i)
+			d.dump(v.Index(i))
+			d.printf("\n")
+		}
+		d.level--
+		d.indent()
+		d.printf("]")
+	case reflect.Struct:
+		d.dumpStruct(v)
+	case reflect.String:
+		d.printf("%q", v.String())
+	default:
+		d.printf("%v", v.Interface())
+	}
+}
+
+// dumpStruct prints every exported field of v, which holds a struct
+// node. Nodes in this tree are plain value types, not pointers, so
+// unlike go/ast they cannot form reference cycles and need no guard
+// against revisiting one.
+func (d *dumper) dumpStruct(v reflect.Value) {
+	t := v.Type()
+	d.printf("%s {\n", t.Name())
+	d.level++
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		d.indent()
+		d.printf("%s: ", f.Name)
+		d.dump(v.Field(i))
+		d.printf("\n")
+	}
+	d.level--
+	d.indent()
+	d.printf("}")
+}
+
+func (d *dumper) dumpPos(p Pos) {
+	if d.fset == nil {
+		d.printf("%d", int(p))
+		return
+	}
+	d.printf("%s", d.fset.Position(p))
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2016, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package sh_test
+
+import (
+	"testing"
+
+	"github.com/dominikh/sh"
+)
+
+func TestSdump(t *testing.T) {
+	f := sh.File{Stmts: []sh.Stmt{
+		{Position: 1, Node: sh.Command{Args: []sh.Word{
+			{Parts: []sh.Node{sh.Lit{ValuePos: 1, Value: "echo"}}},
+		}}},
+	}}
+
+	want := `File {
+    Fset: <nil>
+    Stmts: [
+        0: Stmt {
+            Node: Command {
+                Args: [
+                    0: Word {
+                        Parts: [
+                            0: Lit {
+                                ValuePos: 1
+                                Value: "echo"
+                            }
+                        ]
+                    }
+                ]
+            }
+            Position: 1
+            Negated: false
+            Assigns: []
+            Redirs: []
+            Background: false
+        }
+    ]
+    Comments: []
+}
+`
+	if got := sh.Sdump(nil, f); got != want {
+		t.Errorf("Sdump mismatch:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2016, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package sh
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/dominikh/sh/token"
+)
+
+// MultiError collects the errors produced by parsing a batch of files
+// with ParseFiles. It implements error so callers that only care
+// whether anything failed can keep treating it as a plain error.
+type MultiError []error
+
+func (m MultiError) Error() string {
+	switch len(m) {
+	case 0:
+		return "no errors"
+	case 1:
+		return m[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", m[0], len(m)-1)
+}
+
+// ParseFiles parses each of filenames concurrently, with at most
+// runtime.GOMAXPROCS(0)+10 files open and being parsed at once, and
+// returns the resulting files in the same order as filenames. All of the returned files share a
+// single FileSet, so Pos values can be compared and resolved across
+// files.
+//
+// If any file fails to open or parse, ParseFiles still parses the
+// rest and returns a non-nil MultiError alongside the files that did
+// succeed; a failed file's slot in the result is nil.
+func ParseFiles(filenames []string, opts ParseOptions) ([]*File, error) {
+	fset := token.NewFileSet()
+	files := make([]*File, len(filenames))
+	errs := make([]error, len(filenames))
+
+	procs := runtime.GOMAXPROCS(0)
+	sem := make(chan struct{}, procs+10)
+
+	done := make(chan int, len(filenames))
+	for i, name := range filenames {
+		sem <- struct{}{}
+		go func(i int, name string) {
+			defer func() { <-sem; done <- i }()
+			f, err := os.Open(name)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer f.Close()
+
+			// Parse adds name to fset itself and rebases every
+			// Pos it hands out against it, so files[i] can be
+			// compared and positioned against its siblings.
+			parsed, err := Parse(f, name, fset, opts)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			files[i] = parsed
+		}(i, name)
+	}
+	for range filenames {
+		<-done
+	}
+
+	var me MultiError
+	for _, err := range errs {
+		if err != nil {
+			me = append(me, err)
+		}
+	}
+	if len(me) > 0 {
+		return files, me
+	}
+	return files, nil
+}
@@ -0,0 +1,268 @@
+// Copyright (c) 2016, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+// Package printer implements printing of sh syntax trees in a
+// canonical, gofmt-like style.
+package printer
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/dominikh/sh"
+	"github.com/dominikh/sh/token"
+)
+
+// Config controls how an AST is printed.
+type Config struct {
+	// Indent sets the number of spaces to use for one level of
+	// indentation. If zero, a single tab is used instead.
+	Indent int
+	// Tabs forces tab characters for indentation even when Indent
+	// is non-zero.
+	Tabs bool
+}
+
+// Fprint "pretty-prints" f to w using the default configuration.
+func Fprint(w io.Writer, fset *token.FileSet, f sh.File) error {
+	return Config{}.Fprint(w, fset, f)
+}
+
+// Fprint "pretty-prints" f to w following the configuration in c. fset
+// is used to recover line information from the Pos values in f, so
+// that the output can decide where the source already broke a
+// pipeline or && / || chain onto a new line.
+func (c Config) Fprint(w io.Writer, fset *token.FileSet, f sh.File) error {
+	p := printer{
+		bw:   bufio.NewWriter(w),
+		cfg:  c,
+		fset: fset,
+	}
+	if len(f.Comments) > 0 {
+		p.cm = sh.NewCommentMap(fset, f, f.Comments)
+	}
+	p.stmts(f.Stmts)
+	if err := p.bw.Flush(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// printer walks an AST and writes indented, multi-line shell source.
+type printer struct {
+	bw   *bufio.Writer
+	cfg  Config
+	fset *token.FileSet
+	cm   sh.CommentMap
+
+	level int
+}
+
+func (p *printer) line(pos token.Pos) int { return p.fset.Position(pos).Line }
+
+func (p *printer) writeString(s string) { p.bw.WriteString(s) }
+func (p *printer) writeByte(b byte)     { p.bw.WriteByte(b) }
+
+func (p *printer) newline() {
+	p.writeByte('\n')
+	if p.cfg.Tabs || p.cfg.Indent == 0 {
+		for i := 0; i < p.level; i++ {
+			p.writeByte('\t')
+		}
+		return
+	}
+	for i := 0; i < p.level*p.cfg.Indent; i++ {
+		p.writeByte(' ')
+	}
+}
+
+func (p *printer) stmts(stmts []sh.Stmt) {
+	for i, s := range stmts {
+		if i > 0 {
+			p.sep(stmts[i-1], s)
+		}
+		p.stmt(s)
+	}
+}
+
+// block prints stmts indented one level further, surrounded by the
+// newlines required after a keyword such as do or then.
+func (p *printer) block(stmts []sh.Stmt) {
+	p.level++
+	for i, s := range stmts {
+		if i == 0 {
+			p.newline()
+		} else {
+			p.sep(stmts[i-1], s)
+		}
+		p.stmt(s)
+	}
+	p.level--
+	p.newline()
+}
+
+// sep separates two consecutive statements: a "; " if they started on
+// the same source line, so that input like "echo a; echo b" keeps its
+// single line, or a newline otherwise.
+func (p *printer) sep(prev, cur sh.Stmt) {
+	if p.line(prev.Pos()) == p.line(cur.Pos()) {
+		p.writeString("; ")
+		return
+	}
+	p.newline()
+}
+
+func (p *printer) stmt(s sh.Stmt) {
+	var trailing []*sh.CommentGroup
+	for _, g := range p.cm.Comments(s) {
+		if p.line(g.Pos()) < p.line(s.Pos()) {
+			for _, c := range g.Comments {
+				p.writeString(c.String())
+				p.newline()
+			}
+		} else {
+			trailing = append(trailing, g)
+		}
+	}
+	if s.Negated {
+		p.writeString("! ")
+	}
+	for _, a := range s.Assigns {
+		p.writeString(a.String())
+		p.writeByte(' ')
+	}
+	p.node(s.Node)
+	// Redirect has no field for a heredoc's body, only its delimiter
+	// Word, so there is nothing here to align after the statement;
+	// that needs an AST change and is out of scope for this printer.
+	for _, r := range s.Redirs {
+		p.writeByte(' ')
+		p.writeString(r.String())
+	}
+	if s.Background {
+		p.writeString(" &")
+	}
+	for _, g := range trailing {
+		for _, c := range g.Comments {
+			p.writeString(" ")
+			p.writeString(c.String())
+		}
+	}
+}
+
+func (p *printer) node(n sh.Node) {
+	switch x := n.(type) {
+	case sh.Command:
+		p.writeString(x.String())
+	case sh.Block:
+		p.writeString("{")
+		p.block(x.Stmts)
+		p.writeString("}")
+	case sh.Subshell:
+		p.writeString("(")
+		p.block(x.Stmts)
+		p.writeString(")")
+	case sh.IfStmt:
+		p.ifStmt(x)
+	case sh.WhileStmt:
+		p.writeString("while ")
+		p.stmts(x.Conds)
+		p.writeString("; do")
+		p.block(x.DoStmts)
+		p.writeString("done")
+	case sh.UntilStmt:
+		p.writeString("until ")
+		p.stmts(x.Conds)
+		p.writeString("; do")
+		p.block(x.DoStmts)
+		p.writeString("done")
+	case sh.ForStmt:
+		p.forStmt(x)
+	case sh.CaseStmt:
+		p.caseStmt(x)
+	case sh.BinaryExpr:
+		p.binaryExpr(x)
+	case sh.FuncDecl:
+		if x.BashStyle {
+			p.writeString("function ")
+		}
+		p.writeString(x.Name.String())
+		p.writeString("() ")
+		p.stmt(x.Body)
+	case nil:
+	default:
+		p.writeString(n.String())
+	}
+}
+
+func (p *printer) ifStmt(s sh.IfStmt) {
+	p.writeString("if ")
+	p.stmts(s.Conds)
+	p.writeString("; then")
+	p.block(s.ThenStmts)
+	for _, elif := range s.Elifs {
+		p.writeString("elif ")
+		p.stmts(elif.Conds)
+		p.writeString("; then")
+		p.block(elif.ThenStmts)
+	}
+	if len(s.ElseStmts) > 0 {
+		p.writeString("else")
+		p.block(s.ElseStmts)
+	}
+	p.writeString("fi")
+}
+
+func (p *printer) forStmt(s sh.ForStmt) {
+	p.writeString("for ")
+	p.writeString(s.Name.String())
+	if len(s.WordList) > 0 {
+		p.writeString(" in")
+		for _, w := range s.WordList {
+			p.writeByte(' ')
+			p.writeString(w.String())
+		}
+	}
+	p.writeString("; do")
+	p.block(s.DoStmts)
+	p.writeString("done")
+}
+
+func (p *printer) caseStmt(s sh.CaseStmt) {
+	p.writeString("case ")
+	p.writeString(s.Word.String())
+	p.writeString(" in")
+	p.level++
+	for _, pl := range s.List {
+		p.newline()
+		for i, w := range pl.Patterns {
+			if i > 0 {
+				p.writeString(" | ")
+			}
+			p.writeString(w.String())
+		}
+		p.writeString(")")
+		p.block(pl.Stmts)
+		p.writeString(";;")
+	}
+	p.level--
+	p.newline()
+	p.writeString("esac")
+}
+
+// binaryExpr prints a pipeline or && / || chain, breaking onto a new
+// line whenever the right-hand side started on a new line in the
+// original source.
+func (p *printer) binaryExpr(b sh.BinaryExpr) {
+	p.stmt(b.X)
+	p.writeByte(' ')
+	p.writeString(b.Op.String())
+	if p.line(b.Y.Pos()) > p.line(b.X.Pos()) {
+		p.level++
+		p.newline()
+		p.level--
+	} else {
+		p.writeByte(' ')
+	}
+	p.stmt(b.Y)
+}
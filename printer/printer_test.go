@@ -0,0 +1,64 @@
+// Copyright (c) 2016, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package printer_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dominikh/sh"
+	"github.com/dominikh/sh/printer"
+	"github.com/dominikh/sh/token"
+)
+
+func cmdStmt(pos sh.Pos, name string) sh.Stmt {
+	lit := sh.Lit{ValuePos: pos, Value: name}
+	return sh.Stmt{Position: pos, Node: sh.Command{Args: []sh.Word{{Parts: []sh.Node{lit}}}}}
+}
+
+func TestFprintSameLineSemicolon(t *testing.T) {
+	fset := token.NewFileSet()
+	tf := fset.AddFile("t.sh", 50)
+	tf.AddLine(0)
+	tf.AddLine(20)
+	base := tf.Base()
+
+	f := sh.File{Fset: fset, Stmts: []sh.Stmt{
+		cmdStmt(sh.Pos(base), "echo"),
+		cmdStmt(sh.Pos(base+5), "echo2"),
+		cmdStmt(sh.Pos(base+20), "echo3"),
+	}}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, f); err != nil {
+		t.Fatal(err)
+	}
+	want := "echo; echo2\necho3"
+	if got := buf.String(); got != want {
+		t.Errorf("Fprint = %q, want %q", got, want)
+	}
+}
+
+func TestFprintIfBlock(t *testing.T) {
+	fset := token.NewFileSet()
+	tf := fset.AddFile("t.sh", 50)
+	tf.AddLine(0)
+	tf.AddLine(20)
+	tf.AddLine(40)
+	base := tf.Base()
+
+	cond := cmdStmt(sh.Pos(base), "true")
+	body := cmdStmt(sh.Pos(base+20), "body")
+	ifs := sh.IfStmt{If: sh.Pos(base), Fi: sh.Pos(base + 40), Conds: []sh.Stmt{cond}, ThenStmts: []sh.Stmt{body}}
+	f := sh.File{Fset: fset, Stmts: []sh.Stmt{{Position: sh.Pos(base), Node: ifs}}}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, f); err != nil {
+		t.Fatal(err)
+	}
+	want := "if true; then\n\tbody\nfi"
+	if got := buf.String(); got != want {
+		t.Errorf("Fprint =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2016, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+// Package token holds position information shared by the parser and
+// the syntax tree: a compact Pos offset and the FileSet needed to
+// turn it back into a filename, line, and column.
+package token
+
+import (
+	"sort"
+	"sync"
+)
+
+// Pos is a compact representation of a source position within a
+// FileSet. It is the offset of the position from the start of the
+// FileSet's address space, not from the start of its file, so it
+// fits in a single machine word and can be compared directly.
+//
+// The zero Pos is NoPos; it carries no position information and
+// IsValid reports false for it.
+type Pos int
+
+// NoPos is the zero value for Pos. It is returned by nodes that were
+// not produced by the parser, such as ones built by hand in tests.
+const NoPos Pos = 0
+
+// IsValid reports whether the position is known.
+func (p Pos) IsValid() bool { return p != NoPos }
+
+// Position describes a resolved source position, suitable for error
+// messages and other diagnostics.
+type Position struct {
+	Filename string
+	Offset   int // byte offset, starting at 0
+	Line     int // line number, starting at 1
+	Column   int // column number in bytes, starting at 1
+}
+
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+func (p Position) String() string {
+	if p.Filename == "" {
+		p.Filename = "<unknown>"
+	}
+	if !p.IsValid() {
+		return p.Filename
+	}
+	return p.Filename + ":" + itoa(p.Line) + ":" + itoa(p.Column)
+}
+
+func itoa(i int) string {
+	if i == 0 {
+		return "0"
+	}
+	neg := i < 0
+	if neg {
+		i = -i
+	}
+	var buf [20]byte
+	j := len(buf)
+	for i > 0 {
+		j--
+		buf[j] = byte('0' + i%10)
+		i /= 10
+	}
+	if neg {
+		j--
+		buf[j] = '-'
+	}
+	return string(buf[j:])
+}
+
+// File holds the line offsets for a single source file that has been
+// added to a FileSet, so that a Pos within its range can be turned
+// back into a Position.
+type File struct {
+	name string
+	base int
+	size int
+
+	// lines holds the offset of the start of every line, in order,
+	// relative to the start of the file. lines[0] is always 0.
+	lines []int
+}
+
+// Name returns the file name as given to FileSet.AddFile.
+func (f *File) Name() string { return f.name }
+
+// Base returns the Pos of the first byte in the file.
+func (f *File) Base() int { return f.base }
+
+// Size returns the size of the file in bytes.
+func (f *File) Size() int { return f.size }
+
+// AddLine records the offset of the start of a new line. Offsets
+// must be added in increasing order as the file is scanned.
+func (f *File) AddLine(offset int) {
+	if n := len(f.lines); n == 0 || f.lines[n-1] < offset {
+		f.lines = append(f.lines, offset)
+	}
+}
+
+func (f *File) position(offset int) Position {
+	i := sort.Search(len(f.lines), func(i int) bool { return f.lines[i] > offset }) - 1
+	if i < 0 {
+		i = 0
+	}
+	return Position{
+		Filename: f.name,
+		Offset:   offset,
+		Line:     i + 1,
+		Column:   offset - f.lines[i] + 1,
+	}
+}
+
+// FileSet tracks the source files being parsed by a single parser
+// invocation and hands out Pos values within their combined address
+// space, mirroring the design of go/token's FileSet.
+//
+// A FileSet may be shared by multiple goroutines, e.g. when ParseFiles
+// drives several parses concurrently against one FileSet; mu guards
+// base and files against the resulting concurrent AddFile calls.
+type FileSet struct {
+	mu    sync.RWMutex
+	base  int
+	files []*File
+}
+
+// NewFileSet creates a new, empty FileSet.
+func NewFileSet() *FileSet { return &FileSet{base: 1} }
+
+// AddFile adds a new file of the given name and size to the set and
+// returns it. Positions within the file are obtained by adding a byte
+// offset to the returned File's Base.
+func (s *FileSet) AddFile(name string, size int) *File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := &File{name: name, base: s.base, size: size, lines: []int{0}}
+	s.base += size + 1
+	s.files = append(s.files, f)
+	return f
+}
+
+// File returns the file that contains p, or nil if p does not belong
+// to any file added to the set.
+func (s *FileSet) File(p Pos) *File {
+	offset := int(p)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, f := range s.files {
+		if offset >= f.base && offset <= f.base+f.size {
+			return f
+		}
+	}
+	return nil
+}
+
+// Position resolves p to a filename, line, and column. It returns
+// the zero Position if p is NoPos or unknown to the set.
+func (s *FileSet) Position(p Pos) Position {
+	if !p.IsValid() {
+		return Position{}
+	}
+	f := s.File(p)
+	if f == nil {
+		return Position{}
+	}
+	return f.position(int(p) - f.base)
+}
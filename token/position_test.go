@@ -0,0 +1,63 @@
+// Copyright (c) 2016, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package token_test
+
+import (
+	"testing"
+
+	"github.com/dominikh/sh/token"
+)
+
+func TestFileSetPosition(t *testing.T) {
+	fset := token.NewFileSet()
+	f := fset.AddFile("foo.sh", 20)
+	f.AddLine(0)
+	f.AddLine(5)
+	f.AddLine(12)
+
+	tests := []struct {
+		offset       int
+		line, column int
+	}{
+		{0, 1, 1},
+		{4, 1, 5},
+		{5, 2, 1},
+		{11, 2, 7},
+		{12, 3, 1},
+	}
+	for _, tc := range tests {
+		pos := token.Pos(f.Base() + tc.offset)
+		got := fset.Position(pos)
+		if got.Line != tc.line || got.Column != tc.column {
+			t.Errorf("Position(offset %d) = %d:%d, want %d:%d",
+				tc.offset, got.Line, got.Column, tc.line, tc.column)
+		}
+		if got.Filename != "foo.sh" {
+			t.Errorf("Position(offset %d).Filename = %q, want foo.sh", tc.offset, got.Filename)
+		}
+	}
+}
+
+func TestFileSetMultipleFiles(t *testing.T) {
+	fset := token.NewFileSet()
+	a := fset.AddFile("a.sh", 10)
+	b := fset.AddFile("b.sh", 10)
+
+	if got := fset.Position(token.Pos(a.Base())).Filename; got != "a.sh" {
+		t.Errorf("Position in a.sh reported filename %q", got)
+	}
+	if got := fset.Position(token.Pos(b.Base())).Filename; got != "b.sh" {
+		t.Errorf("Position in b.sh reported filename %q", got)
+	}
+}
+
+func TestNoPos(t *testing.T) {
+	if token.NoPos.IsValid() {
+		t.Errorf("NoPos.IsValid() = true, want false")
+	}
+	fset := token.NewFileSet()
+	if got := fset.Position(token.NoPos); got.IsValid() {
+		t.Errorf("Position(NoPos) = %v, want an invalid Position", got)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2016, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package sh
+
+import "fmt"
+
+// A Visitor's Visit method is invoked for each node encountered by
+// Walk. If the result visitor w is not nil, Walk visits each of the
+// children of node with the visitor w, followed by a call of
+// w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order: it starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor
+// w for each of the non-nil children of node, followed by a call of
+// w.Visit(nil).
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case File:
+		walkStmts(v, n.Stmts)
+	case Stmt:
+		if n.Node != nil {
+			Walk(v, n.Node)
+		}
+		for _, a := range n.Assigns {
+			Walk(v, a)
+		}
+		for _, r := range n.Redirs {
+			Walk(v, r)
+		}
+	case Assign:
+		Walk(v, n.Name)
+		Walk(v, n.Value)
+	case Redirect:
+		if n.N.Value != "" {
+			Walk(v, n.N)
+		}
+		Walk(v, n.Word)
+	case Command:
+		walkWords(v, n.Args)
+	case Subshell:
+		walkStmts(v, n.Stmts)
+	case Block:
+		walkStmts(v, n.Stmts)
+	case IfStmt:
+		walkStmts(v, n.Conds)
+		walkStmts(v, n.ThenStmts)
+		for _, e := range n.Elifs {
+			Walk(v, e)
+		}
+		walkStmts(v, n.ElseStmts)
+	case Elif:
+		walkStmts(v, n.Conds)
+		walkStmts(v, n.ThenStmts)
+	case WhileStmt:
+		walkStmts(v, n.Conds)
+		walkStmts(v, n.DoStmts)
+	case UntilStmt:
+		walkStmts(v, n.Conds)
+		walkStmts(v, n.DoStmts)
+	case ForStmt:
+		Walk(v, n.Name)
+		walkWords(v, n.WordList)
+		walkStmts(v, n.DoStmts)
+	case BinaryExpr:
+		Walk(v, n.X)
+		Walk(v, n.Y)
+	case FuncDecl:
+		Walk(v, n.Name)
+		Walk(v, n.Body)
+	case Word:
+		for _, p := range n.Parts {
+			Walk(v, p)
+		}
+	case Lit:
+		// no children
+	case SglQuoted:
+		// no children
+	case DblQuoted:
+		for _, p := range n.Parts {
+			Walk(v, p)
+		}
+	case CmdSubst:
+		walkStmts(v, n.Stmts)
+	case ParamExp:
+		// no children
+	case ArithmExp:
+		walkWords(v, n.Words)
+	case CaseStmt:
+		Walk(v, n.Word)
+		for _, pl := range n.List {
+			Walk(v, pl)
+		}
+	case PatternList:
+		walkWords(v, n.Patterns)
+		walkStmts(v, n.Stmts)
+	default:
+		panic(fmt.Sprintf("sh.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkStmts(v Visitor, stmts []Stmt) {
+	for _, s := range stmts {
+		Walk(v, s)
+	}
+}
+
+func walkWords(v Visitor, words []Word) {
+	for _, w := range words {
+		Walk(v, w)
+	}
+}
+
+// inspector implements Visitor with a function value, so that Inspect
+// can offer a simpler closure-based API on top of Walk.
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: it starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a
+// call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
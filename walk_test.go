@@ -0,0 +1,98 @@
+// Copyright (c) 2016, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package sh_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dominikh/sh"
+)
+
+// collectTypeNames walks n and records the concrete type name of
+// every node Walk visits.
+func collectTypeNames(n sh.Node) map[string]bool {
+	seen := make(map[string]bool)
+	sh.Inspect(n, func(n sh.Node) bool {
+		if n == nil {
+			return false
+		}
+		seen[reflect.TypeOf(n).Name()] = true
+		return true
+	})
+	return seen
+}
+
+func TestWalkVisitsEveryNodeKind(t *testing.T) {
+	lit := sh.Lit{ValuePos: 1, Value: "x"}
+	word := sh.Word{Parts: []sh.Node{
+		lit,
+		sh.SglQuoted{Quote: 2, Value: "y"},
+		sh.DblQuoted{Quote: 3, Parts: []sh.Node{lit}},
+		sh.ParamExp{Exp: 4, Text: "FOO"},
+		sh.ArithmExp{Exp: 5, Words: []sh.Word{{Parts: []sh.Node{lit}}}},
+		sh.CmdSubst{Left: 6, Stmts: []sh.Stmt{
+			{Position: 6, Node: sh.Command{Args: []sh.Word{{Parts: []sh.Node{lit}}}}},
+		}},
+	}}
+
+	assign := sh.Assign{Name: lit, Value: word}
+	redir := sh.Redirect{OpPos: 7, N: lit, Word: word}
+	leaf := sh.Stmt{Position: 8, Node: sh.Command{Args: []sh.Word{word}}, Assigns: []sh.Assign{assign}, Redirs: []sh.Redirect{redir}}
+
+	elif := sh.Elif{Elif: 9, Conds: []sh.Stmt{leaf}, ThenStmts: []sh.Stmt{leaf}}
+	pl := sh.PatternList{Patterns: []sh.Word{word}, Stmts: []sh.Stmt{leaf}}
+
+	f := sh.File{Stmts: []sh.Stmt{
+		{Position: 10, Node: sh.Block{Lbrace: 10, Rbrace: 11, Stmts: []sh.Stmt{leaf}}},
+		{Position: 12, Node: sh.Subshell{Lparen: 12, Rparen: 13, Stmts: []sh.Stmt{leaf}}},
+		{Position: 14, Node: sh.IfStmt{If: 14, Fi: 15, Conds: []sh.Stmt{leaf}, ThenStmts: []sh.Stmt{leaf}, Elifs: []sh.Elif{elif}, ElseStmts: []sh.Stmt{leaf}}},
+		{Position: 16, Node: sh.WhileStmt{While: 16, Done: 17, Conds: []sh.Stmt{leaf}, DoStmts: []sh.Stmt{leaf}}},
+		{Position: 18, Node: sh.UntilStmt{Until: 18, Done: 19, Conds: []sh.Stmt{leaf}, DoStmts: []sh.Stmt{leaf}}},
+		{Position: 20, Node: sh.ForStmt{For: 20, Done: 21, Name: lit, WordList: []sh.Word{word}, DoStmts: []sh.Stmt{leaf}}},
+		{Position: 22, Node: sh.BinaryExpr{OpPos: 22, X: leaf, Y: leaf}},
+		{Position: 23, Node: sh.FuncDecl{Position: 23, Name: lit, Body: leaf}},
+		{Position: 24, Node: sh.CaseStmt{Case: 24, Esac: 25, Word: word, List: []sh.PatternList{pl}}},
+	}}
+
+	seen := collectTypeNames(f)
+
+	for _, want := range []string{
+		"File", "Stmt", "Assign", "Redirect", "Command", "Block", "Subshell",
+		"IfStmt", "Elif", "WhileStmt", "UntilStmt", "ForStmt", "BinaryExpr",
+		"FuncDecl", "CaseStmt", "PatternList", "Word", "Lit", "SglQuoted",
+		"DblQuoted", "ParamExp", "ArithmExp", "CmdSubst",
+	} {
+		if !seen[want] {
+			t.Errorf("Walk never visited a %s node", want)
+		}
+	}
+}
+
+func TestWalkCallsVisitNilAfterChildren(t *testing.T) {
+	f := sh.File{Stmts: []sh.Stmt{
+		{Position: 1, Node: sh.Command{Args: []sh.Word{{Parts: []sh.Node{sh.Lit{ValuePos: 1, Value: "x"}}}}}},
+	}}
+
+	var calls []sh.Node
+	sh.Walk(walkRecorder{&calls}, f)
+
+	if len(calls) == 0 || calls[len(calls)-1] != nil {
+		t.Fatalf("last Visit call = %v, want a final nil", calls)
+	}
+}
+
+// walkRecorder implements sh.Visitor, recording every node passed to
+// Visit (including the trailing nil) for TestWalkCallsVisitNilAfterChildren.
+type walkRecorder struct {
+	calls *[]sh.Node
+}
+
+func (w walkRecorder) Visit(n sh.Node) sh.Visitor {
+	*w.calls = append(*w.calls, n)
+	if n == nil {
+		return nil
+	}
+	return w
+}